@@ -0,0 +1,42 @@
+package picolog
+
+// Backend is a log event sink. A Logger dispatches every event it
+// accepts to all of its backends; each backend decides independently
+// how (and whether) to render and store that event.
+type Backend interface {
+	// Log handles one log event. prefix is the logger's rendered
+	// bracketed prefix (e.g. "test1][test2"), msg is the fully
+	// formatted message (already printf- or attribute-rendered by the
+	// Logger), and fields carries the structured attrs for backends
+	// that want them, or nil for a plain Printf-style call.
+	Log(level LogLevel, prefix, msg string, fields map[string]string)
+
+	// Flush forces any buffered output to be written out.
+	Flush() error
+
+	// Close releases any resources (files, connections) held by the
+	// backend.
+	Close() error
+}
+
+// levelFilter wraps a Backend so it only receives events at level or
+// higher severity (numerically at or below level, since syslog
+// severities count down from LOG_EMERG).
+type levelFilter struct {
+	level LogLevel
+	Backend
+}
+
+// LevelFilter wraps backend so it only forwards events at level or
+// higher severity, letting a single Logger fan out to backends that
+// each care about a different minimum level.
+func LevelFilter(level LogLevel, backend Backend) Backend {
+	return &levelFilter{level: level, Backend: backend}
+}
+
+func (f *levelFilter) Log(level LogLevel, prefix, msg string, fields map[string]string) {
+	if level > f.level {
+		return
+	}
+	f.Backend.Log(level, prefix, msg, fields)
+}
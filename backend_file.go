@@ -0,0 +1,75 @@
+package picolog
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// fileBackendCalldepth is the number of stack frames between
+// log.Logger.Output and the user code that called one of Logger's
+// logging methods (Debugf, Info, ...): Output -> FileBackend.Log ->
+// Logger.Printf/logAttrs -> Logger.Debugf/Info/... -> user code.
+const fileBackendCalldepth = 4
+
+// FileBackend is a Backend that writes to an *os.File, preserving the
+// original picolog line format: "[prefix] date time message". It is the
+// backend NewLogger uses under the hood.
+type FileBackend struct {
+	mu     sync.Mutex
+	dest   *os.File
+	writer *bufio.Writer
+	logger *log.Logger
+}
+
+// NewFileBackend returns a FileBackend writing to dest.
+func NewFileBackend(dest *os.File) *FileBackend {
+	writer := bufio.NewWriter(dest)
+	return &FileBackend{
+		dest:   dest,
+		writer: writer,
+		logger: log.New(writer, "", log.Ldate|log.Ltime),
+	}
+}
+
+// Log writes one line. Pre-refactor, picolog decided once at
+// construction time whether a Logger's *entire* output got file:line
+// (whenever it was built at LogDebug), because each Logger owned its
+// own *log.Logger outright. Backends are now shared across a Logger and
+// all of its subloggers, and a Logger's effective level can change at
+// runtime via SetLevel, so there's no longer a single frozen "this
+// logger is a debug logger" decision to key off. Instead, file:line is
+// included per event, for literal LogDebug-level events only
+// (Debug/Debugf calls) — this is an intentional change from the
+// pre-refactor behavior, not an incidental side effect of the split.
+func (b *FileBackend) Log(level LogLevel, prefix, msg string, fields map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	flags := log.Ldate | log.Ltime
+	if level == LogDebug {
+		flags |= log.Lshortfile
+	}
+	b.logger.SetFlags(flags)
+	b.logger.SetPrefix(fmt.Sprintf("[%s] ", prefix))
+	b.logger.Output(fileBackendCalldepth, msg)
+	b.writer.Flush()
+}
+
+// Flush forces any buffered output to be written to dest.
+func (b *FileBackend) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writer.Flush()
+}
+
+// Close flushes any buffered output and closes dest.
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.writer.Flush(); err != nil {
+		return err
+	}
+	return b.dest.Close()
+}
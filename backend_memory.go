@@ -0,0 +1,66 @@
+package picolog
+
+import "sync"
+
+// Record is one log event as kept by a MemoryBackend.
+type Record struct {
+	Level  LogLevel
+	Prefix string
+	Msg    string
+	Fields map[string]string
+}
+
+// MemoryBackend is a Backend that keeps the last n Records in a ring
+// buffer, for use in tests and in-process debug pages.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+// NewMemoryBackend returns a MemoryBackend retaining the last n Records
+// logged to it.
+func NewMemoryBackend(n int) *MemoryBackend {
+	return &MemoryBackend{records: make([]Record, n)}
+}
+
+func (b *MemoryBackend) Log(level LogLevel, prefix, msg string, fields map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.records) == 0 {
+		return
+	}
+	b.records[b.next] = Record{Level: level, Prefix: prefix, Msg: msg, Fields: fields}
+	b.next++
+	if b.next == len(b.records) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// Records returns the retained Records in the order they were logged,
+// oldest first.
+func (b *MemoryBackend) Records() []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		out := make([]Record, b.next)
+		copy(out, b.records[:b.next])
+		return out
+	}
+	out := make([]Record, len(b.records))
+	copy(out, b.records[b.next:])
+	copy(out[len(b.records)-b.next:], b.records[:b.next])
+	return out
+}
+
+// Flush is a no-op; MemoryBackend has nothing to buffer.
+func (b *MemoryBackend) Flush() error {
+	return nil
+}
+
+// Close is a no-op; MemoryBackend holds no external resources.
+func (b *MemoryBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,315 @@
+package picolog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupNamePattern matches exactly the backup filenames rotate()
+// produces for a given path: path + "." + a 2006-01-02T15-04-05
+// timestamp, with an optional ".N" disambiguator for same-second
+// rotations and an optional ".gz" suffix when Compress is set.
+const backupNamePattern = `\.\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}(\.\d+)?(\.gz)?$`
+
+// RollingOptions configures a RollingFileBackend's rotation behaviour.
+type RollingOptions struct {
+	// MaxBytes is the size the active log file may reach before it is
+	// rotated. Zero means no size-based rotation.
+	MaxBytes int64
+	// MaxAge is how long the active log file may be written to before
+	// it is rotated, regardless of size. Zero means no age-based
+	// rotation.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep around, oldest
+	// first discarded. Zero means keep them all.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+	// LocalTime uses the local timezone for rotated file names instead
+	// of UTC.
+	LocalTime bool
+}
+
+// countingWriter wraps an *os.File, tallying the number of bytes
+// written to it into size.
+type countingWriter struct {
+	f    *os.File
+	size *int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	*w.size += int64(n)
+	return n, err
+}
+
+// RollingFileBackend is a Backend that writes to path, rotating it out
+// to a timestamped backup (optionally gzip-compressed) once it crosses
+// a size or age threshold, and pruning old backups beyond MaxBackups.
+type RollingFileBackend struct {
+	mu       sync.Mutex
+	path     string
+	opts     RollingOptions
+	file     *os.File
+	logger   *log.Logger
+	size     int64
+	openedAt time.Time
+	// pendingBackup is set once the active file has been renamed out to
+	// a backup but path hasn't yet been successfully reopened (because
+	// rotate failed partway through). While it's set, b.file/b.logger
+	// still point at that backup file, so writes keep landing somewhere
+	// durable instead of being lost, and the next rotate retries only
+	// the reopen, not the rename.
+	pendingBackup string
+}
+
+// NewRollingFile opens (creating if necessary) a rolling log file at
+// path, rotating it according to opts.
+func NewRollingFile(path string, opts RollingOptions) (*RollingFileBackend, error) {
+	b := &RollingFileBackend{path: path, opts: opts}
+	if err := b.open(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// open opens b.path for appending and resets the size/age bookkeeping
+// used to decide when to rotate next.
+func (b *RollingFileBackend) open() error {
+	f, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	b.file = f
+	b.size = info.Size()
+	b.openedAt = time.Now()
+	b.logger = log.New(countingWriter{f: f, size: &b.size}, "", log.Ldate|log.Ltime)
+	return nil
+}
+
+// Log writes one line, rotating first if a threshold has been crossed.
+// A failed rotation is not fatal to the write: rotate never closes or
+// discards the active file without a working replacement in hand, so
+// the message below is always written to an open file (if path was
+// removed out from under the backend, that is the old, now-unlinked
+// descriptor), and rotation is simply retried on the next call. Like FileBackend.Log, file:line is
+// included per event for literal LogDebug-level events only — see the
+// comment on FileBackend.Log for why that's an intentional departure
+// from picolog's pre-refactor per-Logger-verbosity behavior.
+func (b *RollingFileBackend) Log(level LogLevel, prefix, msg string, fields map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.needsRotation() {
+		// Ignored: rotate() already leaves b.file/b.logger pointing at a
+		// writable file on failure, so the write below still succeeds.
+		b.rotate()
+	}
+	flags := log.Ldate | log.Ltime
+	if level == LogDebug {
+		flags |= log.Lshortfile
+	}
+	b.logger.SetFlags(flags)
+	b.logger.SetPrefix(fmt.Sprintf("[%s] ", prefix))
+	b.logger.Output(fileBackendCalldepth, msg)
+}
+
+// needsRotation reports whether either threshold in b.opts has been
+// crossed by the active file. Must be called with b.mu held.
+func (b *RollingFileBackend) needsRotation() bool {
+	if b.opts.MaxBytes > 0 && b.size >= b.opts.MaxBytes {
+		return true
+	}
+	if b.opts.MaxAge > 0 && time.Since(b.openedAt) >= b.opts.MaxAge {
+		return true
+	}
+	return false
+}
+
+// Reopen forces an immediate rotation, regardless of the configured
+// thresholds. Intended to be called from a SIGHUP handler so an
+// external tool like logrotate can manage rotation instead.
+func (b *RollingFileBackend) Reopen() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rotate()
+}
+
+// rotate renames the active file out to a timestamped backup and opens
+// a fresh file at path, gzip-compressing the backup (if requested) and
+// pruning backups beyond MaxBackups once that's done. Must be called
+// with b.mu held.
+//
+// b.file is never closed or abandoned until its replacement is open and
+// working: the rename happens while the old file is still open (its fd
+// stays valid under the new name), so if the rename fails b.file/b.logger
+// are untouched and logging carries on against the pre-rotation file. If
+// the rename succeeds but opening the fresh file fails, b.pendingBackup
+// records the backup path and b.file/b.logger are left pointing at that
+// (still-open, still-writable) backup so no write is lost; the next call
+// to rotate retries only the open, not the rename, since path has
+// already been vacated.
+//
+// If the rename fails because path itself no longer exists (its whole
+// directory was removed out from under the backend, say), there is no
+// backup to preserve: fall through to opening path fresh directly, so
+// the backend recovers on its own once the directory reappears, instead
+// of retrying a rename that can never succeed again.
+func (b *RollingFileBackend) rotate() error {
+	backupPath := b.pendingBackup
+	if backupPath == "" {
+		now := time.Now()
+		if !b.opts.LocalTime {
+			now = now.UTC()
+		}
+		candidate := fmt.Sprintf("%s.%s", b.path, now.Format("2006-01-02T15-04-05"))
+		// Two rotations within the same second would otherwise collide
+		// on the same backupPath and silently clobber each other. The
+		// suffix is zero-padded so matches still sort into rotation
+		// order lexically (".001" < ".010" < ".100") once a single
+		// second sees double-digit rotations.
+		// A compressed backup only exists under its ".gz" name, so that
+		// counts as taken too.
+		for suffix := 1; fileExists(candidate) || fileExists(candidate+".gz"); suffix++ {
+			candidate = fmt.Sprintf("%s.%s.%03d", b.path, now.Format("2006-01-02T15-04-05"), suffix)
+		}
+		switch err := os.Rename(b.path, candidate); {
+		case err == nil:
+			backupPath = candidate
+			b.pendingBackup = backupPath
+		case fileExists(b.path):
+			// path is still there; whatever's wrong (read-only
+			// directory, disk full, ...) may clear, so retry the
+			// rename next time instead of giving up on it.
+			return err
+		}
+		// Otherwise path itself is gone: nothing to back up, so fall
+		// through and try to open it fresh below.
+	}
+
+	newFile, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	old := b.file
+	b.file = newFile
+	b.size = 0
+	b.openedAt = time.Now()
+	b.logger = log.New(countingWriter{f: newFile, size: &b.size}, "", log.Ldate|log.Ltime)
+	b.pendingBackup = ""
+	old.Close()
+
+	if backupPath != "" && b.opts.Compress {
+		compressed, err := compressFile(backupPath)
+		if err != nil {
+			return err
+		}
+		backupPath = compressed
+	}
+	return b.pruneBackups()
+}
+
+// fileExists reports whether path names an existing file.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compressFile gzips path in place, removing the uncompressed original,
+// and returns the compressed file's path.
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dstPath := path + ".gz"
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return "", err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	return dstPath, nil
+}
+
+// pruneBackups removes the oldest rotated backups of b.path beyond
+// MaxBackups. Only files matching backupNamePattern are considered, so
+// unrelated files that merely share path as a glob prefix (e.g. a
+// sibling "path.README") are never touched. Must be called with b.mu
+// held.
+func (b *RollingFileBackend) pruneBackups() error {
+	if b.opts.MaxBackups <= 0 {
+		return nil
+	}
+	candidates, err := filepath.Glob(b.path + ".*")
+	if err != nil {
+		return err
+	}
+	backupRE, err := regexp.Compile(regexp.QuoteMeta(b.path) + backupNamePattern)
+	if err != nil {
+		return err
+	}
+	var matches []string
+	for _, candidate := range candidates {
+		if backupRE.MatchString(candidate) {
+			matches = append(matches, candidate)
+		}
+	}
+	// Sort on the name without ".gz", so "X.gz" still sorts before
+	// "X.001.gz" just as "X" sorts before "X.001".
+	sort.Slice(matches, func(i, j int) bool {
+		return strings.TrimSuffix(matches[i], ".gz") < strings.TrimSuffix(matches[j], ".gz")
+	})
+	if len(matches) <= b.opts.MaxBackups {
+		return nil
+	}
+	for _, old := range matches[:len(matches)-b.opts.MaxBackups] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush forces the active file's contents to stable storage.
+func (b *RollingFileBackend) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file.Sync()
+}
+
+// Close closes the active file.
+func (b *RollingFileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file.Close()
+}
@@ -0,0 +1,87 @@
+package picolog
+
+import (
+	"fmt"
+	"log/syslog"
+	"sync"
+)
+
+// SyslogBackend is a Backend that writes to a local or remote syslog
+// daemon via log/syslog, mapping picolog levels onto syslog priorities.
+// It reconnects once and retries on a write failure, so a daemon
+// restart doesn't permanently wedge the backend.
+type SyslogBackend struct {
+	mu       sync.Mutex
+	network  string
+	addr     string
+	tag      string
+	facility syslog.Priority
+	writer   *syslog.Writer
+}
+
+// NewSyslogBackend dials network/addr (network may be "" for the local
+// syslog socket) and returns a SyslogBackend logging under tag at
+// facility.
+func NewSyslogBackend(network, addr, tag string, facility syslog.Priority) (*SyslogBackend, error) {
+	writer, err := syslog.Dial(network, addr, facility, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogBackend{
+		network:  network,
+		addr:     addr,
+		tag:      tag,
+		facility: facility,
+		writer:   writer,
+	}, nil
+}
+
+func (b *SyslogBackend) Log(level LogLevel, prefix, msg string, fields map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	line := fmt.Sprintf("[%s] %s", prefix, msg)
+	if b.write(level, line) == nil {
+		return
+	}
+	writer, err := syslog.Dial(b.network, b.addr, b.facility, b.tag)
+	if err != nil {
+		return
+	}
+	b.writer = writer
+	b.write(level, line)
+}
+
+// write sends line to the syslog daemon at the priority corresponding
+// to level.
+func (b *SyslogBackend) write(level LogLevel, line string) error {
+	switch level {
+	case LogEmerg:
+		return b.writer.Emerg(line)
+	case LogAlert:
+		return b.writer.Alert(line)
+	case LogCrit:
+		return b.writer.Crit(line)
+	case LogErr:
+		return b.writer.Err(line)
+	case LogWarning:
+		return b.writer.Warning(line)
+	case LogNotice:
+		return b.writer.Notice(line)
+	case LogInfo:
+		return b.writer.Info(line)
+	default:
+		return b.writer.Debug(line)
+	}
+}
+
+// Flush is a no-op; log/syslog writes are unbuffered.
+func (b *SyslogBackend) Flush() error {
+	return nil
+}
+
+// Close closes the underlying syslog connection.
+func (b *SyslogBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writer.Close()
+}
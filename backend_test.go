@@ -0,0 +1,55 @@
+package picolog
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestMultiLoggerFanOut(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_multilogger_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Errorf("Could not open tempfile: %v", err)
+	}
+	mem := NewMemoryBackend(10)
+	l := NewMultiLogger(LogInfo, "fanouttest", NewFileBackend(fo), mem)
+	l.Infof("logging things")
+	fo.Seek(0, 0)
+	out, err := ioutil.ReadAll(fo)
+	if err != nil {
+		t.Errorf("Could not read tempfile: %v", err)
+	}
+	pattern := regexp.MustCompile(`\[fanouttest\]\s+[\s\d:/.]+logging things`)
+	if !pattern.Match(out) {
+		t.Errorf("Wanted a match for %s, got %s", pattern, out)
+	}
+	records := mem.Records()
+	if len(records) != 1 || records[0].Msg != "logging things" {
+		t.Errorf("Wanted one record with msg %q, got %+v", "logging things", records)
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	mem := NewMemoryBackend(10)
+	l := NewMultiLogger(LogDebug, "filtertest", LevelFilter(LogWarning, mem))
+	l.Infof("should be filtered")
+	l.Warningf("should pass")
+	records := mem.Records()
+	if len(records) != 1 || records[0].Msg != "should pass" {
+		t.Errorf("Wanted one record with msg %q, got %+v", "should pass", records)
+	}
+}
+
+func TestSubLoggerInheritsBackends(t *testing.T) {
+	mem := NewMemoryBackend(10)
+	l := NewMultiLogger(LogInfo, "parenttest", mem)
+	sub := l.NewSubLogger("child")
+	sub.Infof("from child")
+	records := mem.Records()
+	if len(records) != 1 || records[0].Msg != "from child" {
+		t.Errorf("Wanted one record with msg %q, got %+v", "from child", records)
+	}
+}
@@ -0,0 +1,59 @@
+package picolog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+// TestZeroValueLoggerIsUsable pins down that a zero-value *Logger no
+// longer panics: the old initializeDefaultLogger assigned to a local
+// copy of the receiver, so ensureInitialized was a no-op.
+func TestZeroValueLoggerIsUsable(t *testing.T) {
+	var l Logger
+	l.Infof("hello from a zero-value logger")
+	l.Info("hello", map[string]string{"from": "zero-value logger"})
+}
+
+// TestConcurrentLoggerUse hammers a logger and its subloggers from many
+// goroutines at once, to be run with -race.
+func TestConcurrentLoggerUse(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_concurrency_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Fatalf("Could not open tempfile: %v", err)
+	}
+	l := NewLogger(LogInfo, "concurrencytest", fo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sub := l.NewSubLogger(fmt.Sprintf("worker%d", i))
+			for j := 0; j < 20; j++ {
+				sub.Infof("message %d", j)
+				sub.Info("structured", map[string]string{"worker": fmt.Sprintf("%d", i)})
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestConcurrentZeroValueLoggerUse hammers a zero-value logger from
+// many goroutines at once, to be run with -race.
+func TestConcurrentZeroValueLoggerUse(t *testing.T) {
+	var l Logger
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Infof("hello")
+		}()
+	}
+	wg.Wait()
+}
@@ -6,40 +6,45 @@ existing solutions either didn't do what I needed or were too weighty.
 package picolog
 
 import (
-	"bufio"
 	"fmt"
-	"log"
 	"log/syslog"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // Logger is a leveled logger type. It can be a sublogger of another
-// logger, and have an arbitrary number of subloggers itself. 
+// logger, and have an arbitrary number of subloggers itself. A Logger
+// dispatches each log event to every one of its backends; see Backend.
+// The zero Logger is usable: its first use falls back to a
+// NewDefaultLogger, and all of its operations are safe to call
+// concurrently from multiple goroutines.
 type Logger struct {
-	logLevel    LogLevel
-	logger      *log.Logger
-	writer      *bufio.Writer
-	destStream *os.File
-	prefix string
-	subloggers []*Logger
+	mu          sync.Mutex
+	once        sync.Once
+	prefix      string
+	path        string
+	fields      map[string]string
+	backends    []Backend
+	subloggers  []*Logger
 	initialized bool
 }
 
 // LogLevel is a type representing the usual syslog log levels from
 // LOG_DEBUG to LOG_EMERG. It does not reflect the go syslog package's
-// concept of 'Priority'. 
+// concept of 'Priority'.
 type LogLevel syslog.Priority
 
 const (
-	LogDebug LogLevel = LogLevel(syslog.LOG_DEBUG)
-	LogInfo = LogLevel(syslog.LOG_INFO)
-	LogNotice = LogLevel(syslog.LOG_NOTICE)
-	LogWarning = LogLevel(syslog.LOG_WARNING)
-	LogErr = LogLevel(syslog.LOG_ERR)
-	LogCrit = LogLevel(syslog.LOG_CRIT)
-	LogAlert = LogLevel(syslog.LOG_ALERT)
-	LogEmerg = LogLevel(syslog.LOG_EMERG)
+	LogDebug   LogLevel = LogLevel(syslog.LOG_DEBUG)
+	LogInfo             = LogLevel(syslog.LOG_INFO)
+	LogNotice           = LogLevel(syslog.LOG_NOTICE)
+	LogWarning          = LogLevel(syslog.LOG_WARNING)
+	LogErr              = LogLevel(syslog.LOG_ERR)
+	LogCrit             = LogLevel(syslog.LOG_CRIT)
+	LogAlert            = LogLevel(syslog.LOG_ALERT)
+	LogEmerg            = LogLevel(syslog.LOG_EMERG)
 )
 
 // ParseLogLevel takes a string and returns a LogLevel according
@@ -91,26 +96,39 @@ func (l LogLevel) String() string {
 	return "invalid log level"
 }
 
-// Return a new Logger. logLevel is a syslog log level,
-// subpackage is used to construct the log prefix, and dest is where to
-// write the log to.
-func NewLogger(logLevel LogLevel, subpackage string, dest *os.File) *Logger {
+// newLogger builds a Logger dispatching to backends, with prefix used
+// for the rendered bracketed prefix and path used as the registry key
+// for runtime level configuration (see SetLevel). logLevel is only ever
+// used to seed that registry entry: a Logger has no level of its own,
+// every call consults GetLevel(path), so SetLevel(path, ...) is what
+// actually controls verbosity from here on.
+func newLogger(logLevel LogLevel, prefix, path string, backends []Backend) *Logger {
 	logger := new(Logger)
-	logger.logLevel = logLevel
-	flags := log.Ldate | log.Ltime
-	// If logging at DEBUG, include file paths and line numbers
-	if logLevel == LogLevel(syslog.LOG_DEBUG) {
-		flags |= log.Lshortfile
-	}
-	logger.prefix = subpackage
-	renderedPrefix := fmt.Sprintf("[%s] ", logger.prefix)
-	logger.destStream = dest
-	logger.writer = bufio.NewWriter(logger.destStream)
-	logger.logger = log.New(logger.writer, renderedPrefix, flags)
+	logger.prefix = prefix
+	logger.path = path
+	logger.backends = backends
 	logger.initialized = true
+	SetLevel(path, logLevel)
 	return logger
 }
 
+// Return a new Logger. logLevel is a syslog log level,
+// subpackage is used to construct the log prefix, and dest is where to
+// write the log to. subpackage also becomes the logger's registry path
+// (see SetLevel), so it is registered at logLevel. NewLogger is sugar
+// for NewMultiLogger with a single NewFileBackend.
+func NewLogger(logLevel LogLevel, subpackage string, dest *os.File) *Logger {
+	return newLogger(logLevel, subpackage, subpackage, []Backend{NewFileBackend(dest)})
+}
+
+// NewMultiLogger returns a new Logger dispatching every log event to all
+// of backends, each of which may be wrapped with LevelFilter to apply
+// its own minimum level. prefix is used both for the rendered bracketed
+// prefix and as the registry path (see SetLevel).
+func NewMultiLogger(logLevel LogLevel, prefix string, backends ...Backend) *Logger {
+	return newLogger(logLevel, prefix, prefix, backends)
+}
+
 // NewDefaultLogger returns a picolog.Logger initialized with workable
 // defaults (outputs to stderr, prefix "default", priority DEBUG).
 // Useful as a fallback when a logger hasn't been initialized.
@@ -118,43 +136,65 @@ func NewDefaultLogger() *Logger {
 	return NewLogger(LogDebug, "default", os.Stderr)
 }
 
-// initializeDefaultLogger takes a (possibly nil) *Logger and allocates
-// and assigns a default logger as returned by NewDefaultLogger.
+// initializeDefaultLogger populates l in place with the values of a
+// fresh NewDefaultLogger. Must be called with l.mu held.
 func (l *Logger) initializeDefaultLogger() {
-	defaultLogger := NewDefaultLogger()
-	l = defaultLogger
+	def := NewDefaultLogger()
+	l.prefix = def.prefix
+	l.path = def.path
+	l.backends = def.backends
+	l.initialized = true
 }
 
 // ensureInitialized checks if the initialized flag has been set for l,
-// and if not initializes a default logger.
+// and if not initializes a default logger in place, so a zero-value
+// *Logger is safe to use. Safe to call concurrently; the fallback runs
+// at most once per Logger.
 func (l *Logger) ensureInitialized() {
-	if !l.initialized {
-		l.initializeDefaultLogger()
-	}
+	l.once.Do(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.initialized {
+			l.initializeDefaultLogger()
+		}
+	})
 }
 
-// NewSubLogger returns a Logger writing to the same stream, with a
-// prefix constructed from the provided prefix and the parent Logger's
-// prefix. Subloggers can be nested.
+// NewSubLogger returns a Logger inheriting the parent Logger's backend
+// set, with a prefix constructed from the provided prefix and the
+// parent Logger's prefix. Subloggers can be nested. The sublogger's
+// registry path is the parent's path with prefix appended,
+// slash-separated (e.g. "test1" and "test2" give "test1/test2"), and is
+// registered at the parent's current level.
 func (l *Logger) NewSubLogger(prefix string) *Logger {
+	l.ensureInitialized()
+	l.mu.Lock()
+	parentPath := l.path
 	subPrefix := fmt.Sprintf("%s][%s", l.prefix, prefix)
-	sub := NewLogger(l.logLevel, subPrefix, l.destStream)
+	subPath := fmt.Sprintf("%s/%s", l.path, prefix)
+	backends := l.backends
+	l.mu.Unlock()
+	sub := newLogger(GetLevel(parentPath), subPrefix, subPath, backends)
+	l.mu.Lock()
 	l.subloggers = append(l.subloggers, sub)
+	l.mu.Unlock()
 	return sub
 }
 
 // Printf is the lowest-level output function of our Logger. Will use a
-// default logger if l is not initialized.
+// default logger if l is not initialized. The level check consults the
+// registry (see SetLevel) on every call, so verbosity can be changed at
+// runtime without reconstructing the logger.
 func (l *Logger) Printf(format string, level LogLevel, v ...interface{}) {
 	l.ensureInitialized()
-	if level <= l.logLevel {
+	l.mu.Lock()
+	path, prefix, backends := l.path, l.prefix, l.backends
+	l.mu.Unlock()
+	if level <= GetLevel(path) {
 		msg := fmt.Sprintf(format, v...)
-		// We use logger.Output rather than logger.Printf
-		// so we can pass a custom calldepth for file
-		// {path,line}-resolution purposes (the default of 2
-		// is only useful when using the Logger type directly).
-		l.logger.Output(3, msg)
-		l.writer.Flush()
+		for _, b := range backends {
+			b.Log(level, prefix, msg, nil)
+		}
 	}
 }
 
@@ -199,3 +239,130 @@ func (l *Logger) Alertf(format string, v ...interface{}) {
 func (l *Logger) Noticef(format string, v ...interface{}) {
 	l.Printf(format, LogNotice, v...)
 }
+
+// Redactor is implemented by values that carry something which should
+// never be written to a log in full, such as a password or a token.
+// Pass the result of Redact(v) as the attrs value instead of the
+// sensitive string itself.
+type Redactor interface {
+	Redacted() string
+}
+
+// Redact returns a run of asterisks the same length as r's underlying
+// value, so it can stand in for that value in a structured log call's
+// attrs map without ever writing the real value out.
+func Redact(r Redactor) string {
+	return strings.Repeat("*", len(r.Redacted()))
+}
+
+// WithFields returns a derived Logger that carries fields into every
+// subsequent structured logging call made on it. Fields given at the
+// call site are merged in on top, so a call-site key wins over a
+// key of the same name set here.
+func (l *Logger) WithFields(fields map[string]string) *Logger {
+	l.ensureInitialized()
+	l.mu.Lock()
+	derived := &Logger{
+		prefix:      l.prefix,
+		path:        l.path,
+		backends:    l.backends,
+		fields:      mergeFields(l.fields, fields),
+		initialized: l.initialized,
+	}
+	l.mu.Unlock()
+	return derived
+}
+
+// mergeFields returns a new map holding parent's entries overwritten by
+// child's entries of the same key.
+func mergeFields(parent, child map[string]string) map[string]string {
+	merged := make(map[string]string, len(parent)+len(child))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range child {
+		merged[k] = v
+	}
+	return merged
+}
+
+// quoteAttrValue renders a single attribute value the way logAttrs wants
+// it formatted: always double-quoted, with quotes, backslashes and other
+// non-printables escaped as Go source would escape them.
+func quoteAttrValue(v string) string {
+	return fmt.Sprintf("%q", v)
+}
+
+// logAttrs is the lowest-level structured output function, mirroring
+// Printf but for the event/attrs API. Will use a default logger if l is
+// not initialized.
+//
+// "event" is reserved for the event argument itself: an attrs (or
+// WithFields) entry keyed "event" would otherwise be re-emitted by the
+// sorted-keys loop below, producing a second, contradictory event= in
+// the rendered line. It's dropped from the merged attrs rather than
+// silently overwriting the real event.
+func (l *Logger) logAttrs(level LogLevel, event string, attrs map[string]string) {
+	l.ensureInitialized()
+	l.mu.Lock()
+	path, prefix, backends, loggerFields := l.path, l.prefix, l.backends, l.fields
+	l.mu.Unlock()
+	if level <= GetLevel(path) {
+		merged := mergeFields(loggerFields, attrs)
+		delete(merged, "event")
+		keys := make([]string, 0, len(merged))
+		for k := range merged {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		msg := fmt.Sprintf("[%s] event=%s", strings.ToUpper(level.String()), quoteAttrValue(event))
+		for _, k := range keys {
+			msg += fmt.Sprintf(" %s=%s", k, quoteAttrValue(merged[k]))
+		}
+		for _, b := range backends {
+			b.Log(level, prefix, msg, merged)
+		}
+	}
+}
+
+// Debug logs one structured event at LOG_DEBUG.
+func (l *Logger) Debug(event string, attrs map[string]string) {
+	l.logAttrs(LogDebug, event, attrs)
+}
+
+// Info logs one structured event at LOG_INFO.
+func (l *Logger) Info(event string, attrs map[string]string) {
+	l.logAttrs(LogInfo, event, attrs)
+}
+
+// Notice logs one structured event at LOG_NOTICE.
+func (l *Logger) Notice(event string, attrs map[string]string) {
+	l.logAttrs(LogNotice, event, attrs)
+}
+
+// Warning logs one structured event at LOG_WARNING.
+func (l *Logger) Warning(event string, attrs map[string]string) {
+	l.logAttrs(LogWarning, event, attrs)
+}
+
+// Error logs one structured event at LOG_ERR.
+func (l *Logger) Error(event string, attrs map[string]string) {
+	l.logAttrs(LogErr, event, attrs)
+}
+
+// Alert logs one structured event at LOG_ALERT.
+func (l *Logger) Alert(event string, attrs map[string]string) {
+	l.logAttrs(LogAlert, event, attrs)
+}
+
+// Emerg logs one structured event at LOG_EMERG.
+func (l *Logger) Emerg(event string, attrs map[string]string) {
+	l.logAttrs(LogEmerg, event, attrs)
+}
+
+// Fatal logs one structured event at LOG_ERR, and then exits with an
+// error code.
+func (l *Logger) Fatal(event string, attrs map[string]string) {
+	l.logAttrs(LogErr, event, attrs)
+	os.Exit(1)
+}
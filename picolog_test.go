@@ -86,3 +86,87 @@ func TestParseLogLevel(t *testing.T) {
 		t.Errorf("Successfully parsed invalid log level.")
 	}
 }
+
+func TestStructuredLogging(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_structured_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Errorf("Could not open tempfile: %v", err)
+	}
+	l := NewLogger(LogInfo, "test1", fo)
+	l.Info("failed to open file", map[string]string{
+		"error": "is a directory",
+		"path":  "data.bin",
+	})
+	fo.Seek(0, 0)
+	out, err := ioutil.ReadAll(fo)
+	if err != nil {
+		t.Errorf("Could not read tempfile: %v", err)
+	}
+	pattern := regexp.MustCompile(`\[test1\]\s+[\s\d:/.]+\[INFO\] event="failed to open file" error="is a directory" path="data.bin"`)
+	if !pattern.Match(out) {
+		t.Errorf("Wanted a match for %s, got %s", pattern, out)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_withfields_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Errorf("Could not open tempfile: %v", err)
+	}
+	l := NewLogger(LogInfo, "test1", fo)
+	sub := l.WithFields(map[string]string{"request_id": "abc", "env": "prod"})
+	sub.Info("handled request", map[string]string{"env": "staging"})
+	fo.Seek(0, 0)
+	out, err := ioutil.ReadAll(fo)
+	if err != nil {
+		t.Errorf("Could not read tempfile: %v", err)
+	}
+	pattern := regexp.MustCompile(`event="handled request" env="staging" request_id="abc"`)
+	if !pattern.Match(out) {
+		t.Errorf("Wanted a match for %s, got %s", pattern, out)
+	}
+}
+
+func TestStructuredLoggingReservedEventKey(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_event_collision_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Errorf("Could not open tempfile: %v", err)
+	}
+	l := NewLogger(LogInfo, "test1", fo)
+	l.Info("real event", map[string]string{"event": "attacker-controlled"})
+	fo.Seek(0, 0)
+	out, err := ioutil.ReadAll(fo)
+	if err != nil {
+		t.Errorf("Could not read tempfile: %v", err)
+	}
+	pattern := regexp.MustCompile(`event="real event"`)
+	if !pattern.Match(out) {
+		t.Errorf("Wanted a match for %s, got %s", pattern, out)
+	}
+	if strings.Contains(string(out), "attacker-controlled") {
+		t.Errorf("Wanted the reserved \"event\" attrs key to be dropped, got %s", out)
+	}
+	if n := strings.Count(string(out), "event="); n != 1 {
+		t.Errorf("Wanted exactly one event= in the rendered line, got %d: %s", n, out)
+	}
+}
+
+type redactedString string
+
+func (r redactedString) Redacted() string {
+	return string(r)
+}
+
+func TestRedact(t *testing.T) {
+	got := Redact(redactedString("hunter2"))
+	want := "*******"
+	if got != want {
+		t.Errorf("Wanted %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,78 @@
+package picolog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// registry holds the current log level for every path that has been
+// registered by a Logger, plus any levels set directly through SetLevel
+// or ParseLogLevelConfig. Paths are slash-separated subsystem names,
+// e.g. "test1/test2/test3", mirroring capnslog's one-logger-per-
+// subsystem model.
+var registry = struct {
+	mu     sync.RWMutex
+	levels map[string]LogLevel
+}{levels: make(map[string]LogLevel)}
+
+// SetLevel sets path's registered log level, overriding whatever level
+// it was constructed or last set with. Every Logger registered under
+// path picks up the new level on its very next call, with no restart
+// required. The special path "*" sets a fallback level used by GetLevel
+// for any path without an entry of its own.
+func SetLevel(path string, lvl LogLevel) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.levels[path] = lvl
+}
+
+// GetLevel returns path's currently registered log level. If path has
+// no entry of its own, the "*" wildcard entry is used as a fallback; if
+// neither is present, LogInfo is returned.
+func GetLevel(path string) LogLevel {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if lvl, ok := registry.levels[path]; ok {
+		return lvl
+	}
+	if lvl, ok := registry.levels["*"]; ok {
+		return lvl
+	}
+	return LogInfo
+}
+
+// ParseLogLevelConfig applies a comma-separated list of path=level
+// pairs, such as "test1=info,test1/test2=debug,*=warning", setting each
+// path's registered level in turn. Stops and returns an error on the
+// first malformed entry or unrecognised level.
+func ParseLogLevelConfig(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("Invalid log level config entry: %s", entry)
+		}
+		path := strings.TrimSpace(parts[0])
+		lvl, err := ParseLogLevel(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return err
+		}
+		SetLevel(path, lvl)
+	}
+	return nil
+}
+
+// WalkLoggers calls fn once for every path currently in the registry,
+// with its current level, so callers can expose the live configuration
+// through their own HTTP or debug surface.
+func WalkLoggers(fn func(path string, lvl LogLevel)) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for path, lvl := range registry.levels {
+		fn(path, lvl)
+	}
+}
@@ -0,0 +1,84 @@
+package picolog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSetGetLevel(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_registry_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Errorf("Could not open tempfile: %v", err)
+	}
+	l := NewLogger(LogWarning, "registrytest1", fo)
+	if got := GetLevel("registrytest1"); got != LogWarning {
+		t.Errorf("Wanted %v, got %v", LogWarning, got)
+	}
+	SetLevel("registrytest1", LogDebug)
+	if got := GetLevel("registrytest1"); got != LogDebug {
+		t.Errorf("Wanted %v, got %v", LogDebug, got)
+	}
+	// Printf must consult the registry on every call, not the level
+	// frozen at construction time.
+	l.Debugf("now visible")
+	fo.Seek(0, 0)
+	out, err := ioutil.ReadAll(fo)
+	if err != nil {
+		t.Errorf("Could not read tempfile: %v", err)
+	}
+	if len(out) == 0 {
+		t.Errorf("Wanted a debug line after raising the registered level, got nothing")
+	}
+}
+
+func TestSubLoggerPath(t *testing.T) {
+	fo, err := ioutil.TempFile(".", "picolog_registry_sub_test_out")
+	fname := fo.Name()
+	defer os.Remove(fname)
+	if err != nil {
+		t.Errorf("Could not open tempfile: %v", err)
+	}
+	l := NewLogger(LogWarning, "registrytest2", fo)
+	sub := l.NewSubLogger("child")
+	_ = sub
+	if got := GetLevel("registrytest2/child"); got != LogWarning {
+		t.Errorf("Wanted %v, got %v", LogWarning, got)
+	}
+}
+
+func TestParseLogLevelConfig(t *testing.T) {
+	if err := ParseLogLevelConfig("registrytest3=info,registrytest3/child=debug,*=warning"); err != nil {
+		t.Errorf("%v", err)
+	}
+	if got := GetLevel("registrytest3"); got != LogInfo {
+		t.Errorf("Wanted %v, got %v", LogInfo, got)
+	}
+	if got := GetLevel("registrytest3/child"); got != LogDebug {
+		t.Errorf("Wanted %v, got %v", LogDebug, got)
+	}
+	if got := GetLevel("registrytest3/unregistered"); got != LogWarning {
+		t.Errorf("Wanted %v, got %v", LogWarning, got)
+	}
+	if err := ParseLogLevelConfig("badentry"); err == nil {
+		t.Errorf("Successfully parsed invalid log level config.")
+	}
+	if err := ParseLogLevelConfig("registrytest3=bogus"); err == nil {
+		t.Errorf("Successfully parsed invalid log level.")
+	}
+}
+
+func TestWalkLoggers(t *testing.T) {
+	SetLevel("registrytest4", LogNotice)
+	found := false
+	WalkLoggers(func(path string, lvl LogLevel) {
+		if path == "registrytest4" && lvl == LogNotice {
+			found = true
+		}
+	})
+	if !found {
+		t.Errorf("WalkLoggers did not enumerate registrytest4")
+	}
+}